@@ -0,0 +1,375 @@
+package httpware
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// ScopesKey is the context key for the authenticated token's scopes.
+const ScopesKey contextKey = "scopes"
+
+// ClaimNames maps JWT claim names to the context values Auth populates.
+type ClaimNames struct {
+	// UserID is the claim holding the user ID. Defaults to "sub".
+	UserID string
+	// TenantID is the claim holding the tenant ID. Defaults to "tenant_id".
+	TenantID string
+	// TenantSlug is the claim holding the tenant slug. Defaults to "tenant_slug".
+	TenantSlug string
+	// Scopes is the claim holding granted scopes, either a space-delimited
+	// string (OAuth2 "scope" convention) or a JSON array of strings.
+	// Defaults to "scope".
+	Scopes string
+}
+
+// AuthConfig configures the Auth middleware.
+type AuthConfig struct {
+	// JWKSURL is fetched periodically to refresh the key set used to verify
+	// token signatures. Required.
+	JWKSURL string
+
+	// RefreshInterval is how often the JWKS is re-fetched in the background.
+	// Defaults to 1h.
+	RefreshInterval time.Duration
+
+	// HTTPClient fetches the JWKS document. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Issuer, if set, is required to match the token's "iss" claim.
+	Issuer string
+
+	// Audience, if set, is required to be present in the token's "aud" claim.
+	Audience string
+
+	// ClaimNames customizes which claims populate context. Zero-valued
+	// fields fall back to their defaults.
+	ClaimNames ClaimNames
+
+	// Logger records JWKS refresh failures so a down or misconfigured JWKS
+	// endpoint is visible instead of silently rejecting every request as an
+	// "invalid token". May be nil to disable logging.
+	Logger *zap.Logger
+}
+
+// Auth middleware validates `Authorization: Bearer` JWTs against a JWKS URL,
+// selecting the verification key by the token's "kid" header and refreshing
+// the key set periodically in the background. It verifies iss/aud/exp/nbf
+// and populates UserIDKey, TenantIDKey, TenantSlugKey, and ScopesKey in
+// context from the configured claim names. Invalid or missing tokens get
+// 401 Unauthorized.
+func Auth(cfg AuthConfig) func(http.Handler) http.Handler {
+	if cfg.RefreshInterval == 0 {
+		cfg.RefreshInterval = time.Hour
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.ClaimNames.UserID == "" {
+		cfg.ClaimNames.UserID = "sub"
+	}
+	if cfg.ClaimNames.TenantID == "" {
+		cfg.ClaimNames.TenantID = "tenant_id"
+	}
+	if cfg.ClaimNames.TenantSlug == "" {
+		cfg.ClaimNames.TenantSlug = "tenant_slug"
+	}
+	if cfg.ClaimNames.Scopes == "" {
+		cfg.ClaimNames.Scopes = "scope"
+	}
+
+	jwks := newJWKSCache(cfg.JWKSURL, cfg.HTTPClient, cfg.RefreshInterval, cfg.Logger)
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+	}
+	if cfg.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(cfg.Audience))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenStr, ok := bearerToken(r)
+			if !ok {
+				renderError(w, NewRequestError(http.StatusUnauthorized, "missing bearer token").WithCode("unauthorized"), GetRequestID(r.Context()))
+				return
+			}
+
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+				kid, _ := t.Header["kid"].(string)
+				key, ok := jwks.key(kid)
+				if !ok {
+					return nil, fmt.Errorf("auth: unknown key id %q", kid)
+				}
+				return key, nil
+			}, parserOpts...)
+			if err != nil {
+				renderError(w, NewRequestError(http.StatusUnauthorized, "invalid token").WithCode("unauthorized").WithCause(err), GetRequestID(r.Context()))
+				return
+			}
+
+			ctx := r.Context()
+			if v, ok := claims[cfg.ClaimNames.UserID].(string); ok && v != "" {
+				ctx = WithUserID(ctx, v)
+			}
+			if v, ok := claims[cfg.ClaimNames.TenantID].(string); ok && v != "" {
+				ctx = WithTenantID(ctx, v)
+			}
+			if v, ok := claims[cfg.ClaimNames.TenantSlug].(string); ok && v != "" {
+				ctx = WithTenantSlug(ctx, v)
+			}
+			ctx = context.WithValue(ctx, ScopesKey, extractScopes(claims, cfg.ClaimNames.Scopes))
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// bearerToken extracts the token from a request's Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+	authz := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authz, prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(authz, prefix))
+	return token, token != ""
+}
+
+// extractScopes reads scopeClaim from claims as either a space-delimited
+// string or a JSON array of strings.
+func extractScopes(claims jwt.MapClaims, scopeClaim string) []string {
+	switch v := claims[scopeClaim].(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		scopes := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	default:
+		return nil
+	}
+}
+
+// GetScopes returns the scopes granted to the authenticated token, or nil
+// if none were found or Auth has not run.
+func GetScopes(ctx context.Context) []string {
+	scopes, _ := ctx.Value(ScopesKey).([]string)
+	return scopes
+}
+
+// RequireScopes returns middleware that responds 403 Forbidden unless the
+// context (populated by Auth) carries every one of scopes.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted := make(map[string]struct{})
+			for _, s := range GetScopes(r.Context()) {
+				granted[s] = struct{}{}
+			}
+			for _, required := range scopes {
+				if _, ok := granted[required]; !ok {
+					renderError(w, NewRequestError(http.StatusForbidden, "insufficient scope").WithCode("forbidden"), GetRequestID(r.Context()))
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DefaultClaimsExtractor is a TenantConfig.ClaimsExtractor compatible with
+// TenantV2, reading the tenant ID and slug that Auth already populated in
+// context. Wire it in to avoid each service building its own auth-client
+// shim just to feed TenantV2.
+func DefaultClaimsExtractor(ctx context.Context) (tenantID, tenantSlug string, ok bool) {
+	tenantID = GetTenantID(ctx)
+	tenantSlug = GetTenantSlug(ctx)
+	return tenantID, tenantSlug, tenantID != "" || tenantSlug != ""
+}
+
+// jwk is a single entry of a JWKS document. Only RSA keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwksDocument is the JWKS document shape: {"keys": [...]}.
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwksCache periodically fetches a JWKS URL and serves RSA public keys by
+// key ID, so Auth never blocks a request on a network call.
+type jwksCache struct {
+	url        string
+	httpClient *http.Client
+	log        *zap.Logger
+
+	mu       sync.RWMutex
+	keys     map[string]*rsa.PublicKey
+	lastErr  error
+	lastSync time.Time
+}
+
+// initialRetryBackoff and maxInitialRetryBackoff bound how aggressively
+// newJWKSCache retries after a failed startup fetch, instead of leaving
+// every request rejected until the next full RefreshInterval (up to 1h by
+// default).
+const (
+	initialRetryBackoff    = time.Second
+	maxInitialRetryBackoff = 30 * time.Second
+)
+
+// newJWKSCache creates a jwksCache, performing an initial synchronous fetch
+// and then refreshing every interval in the background for the lifetime of
+// the process. If the initial fetch fails (e.g. a transient network blip at
+// startup), it is retried with exponential backoff until it succeeds, rather
+// than waiting a full RefreshInterval. Refresh failures are logged (if log
+// is non-nil) rather than discarded, so a down or misconfigured JWKS
+// endpoint is diagnosable instead of silently rejecting every request as an
+// "invalid token".
+func newJWKSCache(url string, httpClient *http.Client, interval time.Duration, log *zap.Logger) *jwksCache {
+	c := &jwksCache{url: url, httpClient: httpClient, log: log, keys: make(map[string]*rsa.PublicKey)}
+	c.refreshAndLog(context.Background())
+
+	go c.refreshLoop(interval)
+
+	return c
+}
+
+// refreshLoop retries quickly after a failed initial fetch, then settles
+// into refreshing every interval once a fetch has succeeded.
+func (c *jwksCache) refreshLoop(interval time.Duration) {
+	backoff := initialRetryBackoff
+	for c.failed() {
+		time.Sleep(backoff)
+		c.refreshAndLog(context.Background())
+		backoff *= 2
+		if backoff > maxInitialRetryBackoff {
+			backoff = maxInitialRetryBackoff
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.refreshAndLog(context.Background())
+	}
+}
+
+// failed reports whether the most recent refresh attempt failed.
+func (c *jwksCache) failed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr != nil
+}
+
+// refreshAndLog runs refresh, records the outcome, and logs failures.
+func (c *jwksCache) refreshAndLog(ctx context.Context) {
+	err := c.refresh(ctx)
+
+	c.mu.Lock()
+	c.lastErr = err
+	c.lastSync = time.Now()
+	c.mu.Unlock()
+
+	if err != nil && c.log != nil {
+		c.log.Error("auth: JWKS refresh failed", zap.String("url", c.url), zap.Error(err))
+	}
+}
+
+// refresh fetches and parses the JWKS document, replacing the cached key set.
+func (c *jwksCache) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return fmt.Errorf("auth: build JWKS request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth: fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth: JWKS endpoint returned %d", resp.StatusCode)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("auth: decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, key := range doc.Keys {
+		if key.Kty != "RSA" || key.Kid == "" {
+			continue
+		}
+		pub, err := parseRSAPublicKey(key.N, key.E)
+		if err != nil {
+			continue
+		}
+		keys[key.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+
+	return nil
+}
+
+// key returns the cached RSA public key for kid, if known.
+func (c *jwksCache) key(kid string) (*rsa.PublicKey, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// parseRSAPublicKey builds an *rsa.PublicKey from a JWK's base64url-encoded
+// modulus (n) and exponent (e).
+func parseRSAPublicKey(n, e string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decode modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decode exponent: %w", err)
+	}
+	if len(eBytes) == 0 {
+		return nil, fmt.Errorf("auth: empty exponent")
+	}
+
+	eInt := new(big.Int).SetBytes(eBytes)
+	if !eInt.IsInt64() || eInt.Sign() <= 0 {
+		return nil, fmt.Errorf("auth: exponent out of range")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(eInt.Int64()),
+	}, nil
+}
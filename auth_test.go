@@ -0,0 +1,74 @@
+package httpware
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestParseRSAPublicKey(t *testing.T) {
+	// n is arbitrary; the subtests that use it test only e's bounds-handling.
+	n := base64.RawURLEncoding.EncodeToString([]byte{0x01, 0x02, 0x03})
+
+	t.Run("standard 3-byte exponent (65537)", func(t *testing.T) {
+		e := base64.RawURLEncoding.EncodeToString([]byte{0x01, 0x00, 0x01})
+		pub, err := parseRSAPublicKey(n, e)
+		if err != nil {
+			t.Fatalf("parseRSAPublicKey() error = %v", err)
+		}
+		if pub.E != 65537 {
+			t.Errorf("E = %d, want 65537", pub.E)
+		}
+	})
+
+	t.Run("oversized exponent is rejected, not a panic", func(t *testing.T) {
+		// 9 raw bytes previously overflowed the fixed 8-byte buffer in
+		// parseRSAPublicKey and panicked with "slice bounds out of range".
+		// 0x01 followed by 8 zero bytes is 2^64, too large for an int64.
+		e := base64.RawURLEncoding.EncodeToString(append([]byte{0x01}, make([]byte, 8)...))
+		if _, err := parseRSAPublicKey(n, e); err == nil {
+			t.Fatal("parseRSAPublicKey() error = nil, want error for an oversized exponent")
+		}
+	})
+
+	t.Run("empty exponent is rejected", func(t *testing.T) {
+		if _, err := parseRSAPublicKey(n, ""); err == nil {
+			t.Fatal("parseRSAPublicKey() error = nil, want error for an empty exponent")
+		}
+	})
+
+	t.Run("invalid base64 modulus is rejected", func(t *testing.T) {
+		if _, err := parseRSAPublicKey("not-valid-base64!!!", "AQAB"); err == nil {
+			t.Fatal("parseRSAPublicKey() error = nil, want error for invalid base64")
+		}
+	})
+}
+
+func TestExtractScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{"space-delimited string", "read write admin", []string{"read", "write", "admin"}},
+		{"json array", []interface{}{"read", "write"}, []string{"read", "write"}},
+		{"missing claim", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{}
+			if tt.value != nil {
+				claims["scope"] = tt.value
+			}
+			got := extractScopes(claims, "scope")
+			if len(got) != len(tt.want) {
+				t.Fatalf("extractScopes() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("extractScopes() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package httpware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// errorCaptureKey is the context key ErrorHandler uses to let a Handler-wrapped
+// HandlerFunc hand its error back up the middleware chain.
+const errorCaptureKey contextKey = "error_capture"
+
+// capturedError carries an error alongside the stack at the point it was
+// returned, so ErrorHandler can log the originating call path rather than
+// its own frame.
+type capturedError struct {
+	err   error
+	stack string
+}
+
+// RequestError is a typed error carrying the HTTP status, client-facing
+// message, and machine-readable code that ErrorHandler renders into the
+// standard JSON error envelope. Use the With*/Set* methods to build one
+// fluently, or the New*Error helpers for common cases.
+type RequestError struct {
+	status  int
+	message string
+	code    string
+	cause   error
+}
+
+// NewRequestError creates a RequestError with the given status and message.
+func NewRequestError(status int, message string) *RequestError {
+	return &RequestError{status: status, message: message}
+}
+
+// NewValidationError creates a 400 Bad Request RequestError with code
+// "validation", for rejecting malformed input.
+func NewValidationError(message string) *RequestError {
+	return NewRequestError(http.StatusBadRequest, message).WithCode("validation")
+}
+
+// SetStatus sets the HTTP status code and returns the RequestError for chaining.
+func (e *RequestError) SetStatus(status int) *RequestError {
+	e.status = status
+	return e
+}
+
+// SetMessage sets the client-facing message and returns the RequestError for chaining.
+func (e *RequestError) SetMessage(message string) *RequestError {
+	e.message = message
+	return e
+}
+
+// WithCause attaches an underlying error for logging (never sent to the
+// client) and returns the RequestError for chaining.
+func (e *RequestError) WithCause(cause error) *RequestError {
+	e.cause = cause
+	return e
+}
+
+// WithCode sets the machine-readable error code and returns the RequestError
+// for chaining.
+func (e *RequestError) WithCode(code string) *RequestError {
+	e.code = code
+	return e
+}
+
+// Status returns the HTTP status code, defaulting to 500 if unset.
+func (e *RequestError) Status() int {
+	if e.status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.status
+}
+
+// Code returns the machine-readable error code, if any.
+func (e *RequestError) Code() string {
+	return e.code
+}
+
+// Error implements the error interface.
+func (e *RequestError) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+// Unwrap returns the underlying cause, if any, so errors.Is/As work through
+// a RequestError.
+func (e *RequestError) Unwrap() error {
+	return e.cause
+}
+
+// HandlerFunc is like http.HandlerFunc but returns an error instead of
+// writing failures directly. Wrap one with Handler and mount ErrorHandler
+// upstream to get a consistent error response for every route.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handler adapts a HandlerFunc to http.Handler. If fn returns an error, it is
+// handed to the nearest upstream ErrorHandler for rendering; if none is
+// installed, Handler renders it directly using the same envelope.
+func Handler(fn HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := fn(w, r)
+		if err == nil {
+			return
+		}
+		// Snapshot the stack here, at the point the error is returned, so
+		// ErrorHandler logs the originating call path instead of its own frame.
+		stack := string(debug.Stack())
+		if capture, ok := r.Context().Value(errorCaptureKey).(*capturedError); ok {
+			capture.err = err
+			capture.stack = stack
+			return
+		}
+		writeError(w, r, nil, err, stack)
+	})
+}
+
+// ErrorHandler middleware renders errors from Handler-wrapped routes into a
+// consistent JSON envelope: {"error":{"code":..,"message":..,"request_id":..}}.
+// 5xx errors are logged with the stack captured where the error was
+// returned; RequestErrors with a 4xx status (e.g. from NewValidationError)
+// are logged at a lower level without one.
+func ErrorHandler(log *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			captured := &capturedError{}
+			ctx := context.WithValue(r.Context(), errorCaptureKey, captured)
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			if captured.err != nil {
+				writeError(w, r, log, captured.err, captured.stack)
+			}
+		})
+	}
+}
+
+// writeError renders err as the standard JSON error envelope and logs it.
+// stack is the call path captured by Handler when err was returned; pass ""
+// if unavailable.
+func writeError(w http.ResponseWriter, r *http.Request, log *zap.Logger, err error, stack string) {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		reqErr = NewRequestError(http.StatusInternalServerError, "internal server error").WithCause(err)
+	}
+
+	status := reqErr.Status()
+	requestID := GetRequestID(r.Context())
+
+	if log != nil {
+		fields := []zap.Field{
+			zap.Int("status", status),
+			zap.String("path", r.URL.Path),
+			zap.String("method", r.Method),
+			zap.String("request_id", requestID),
+			zap.Error(err),
+		}
+		if status >= 500 {
+			if stack != "" {
+				fields = append(fields, zap.String("stack", stack))
+			}
+			log.Error("request error", fields...)
+		} else {
+			log.Warn("request error", fields...)
+		}
+	}
+
+	renderError(w, reqErr, requestID)
+}
+
+// renderError writes reqErr as the standard JSON error envelope, with no
+// logging. Used directly by middleware that renders its own errors (such as
+// TenantV2, and Recover, which logs the panic itself).
+func renderError(w http.ResponseWriter, reqErr *RequestError, requestID string) {
+	body := map[string]any{
+		"error": map[string]any{
+			"code":       reqErr.Code(),
+			"message":    reqErr.message,
+			"request_id": requestID,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reqErr.Status())
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -7,6 +7,8 @@
 //   - Logging: Structured HTTP request logging
 //   - Recover: Panic recovery with logging
 //   - CORS: Cross-Origin Resource Sharing headers
+//   - Tracing: OpenTelemetry spans with W3C/B3 context propagation
+//   - Auth: JWT validation against a JWKS URL, with scope enforcement
 //
 // Usage with Chi router:
 //
@@ -151,7 +153,8 @@ func TenantV2(cfg TenantConfig) func(http.Handler) http.Handler {
 
 			// Enforce required tenant
 			if cfg.Required && tenantID == "" && tenantSlug == "" {
-				http.Error(w, `{"error":"tenant context required"}`, http.StatusBadRequest)
+				reqErr := NewRequestError(http.StatusBadRequest, "tenant context required").WithCode("tenant_required")
+				renderError(w, reqErr, GetRequestID(r.Context()))
 				return
 			}
 
@@ -193,6 +196,12 @@ func Logging(log *zap.Logger) func(http.Handler) http.Handler {
 			if tenantSlug := GetTenantSlug(r.Context()); tenantSlug != "" {
 				fields = append(fields, zap.String("tenant_slug", tenantSlug))
 			}
+			if traceID := TraceIDFromContext(r.Context()); traceID != "" {
+				fields = append(fields, zap.String("trace_id", traceID))
+			}
+			if spanID := SpanIDFromContext(r.Context()); spanID != "" {
+				fields = append(fields, zap.String("span_id", spanID))
+			}
 
 			log.Info("http request", fields...)
 		})
@@ -206,14 +215,16 @@ func Recover(log *zap.Logger) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
+					requestID := GetRequestID(r.Context())
 					log.Error("panic recovered",
 						zap.Any("error", err),
 						zap.String("path", r.URL.Path),
 						zap.String("method", r.Method),
-						zap.String("request_id", GetRequestID(r.Context())),
+						zap.String("request_id", requestID),
 						zap.String("stack", string(debug.Stack())),
 					)
-					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+					reqErr := NewRequestError(http.StatusInternalServerError, "internal server error").WithCode("panic")
+					renderError(w, reqErr, requestID)
 				}
 			}()
 			next.ServeHTTP(w, r)
@@ -331,10 +342,12 @@ func WithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
 
-// responseWriter wraps http.ResponseWriter to capture status code.
+// responseWriter wraps http.ResponseWriter to capture status code and
+// response size.
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytesOut   int
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -342,6 +355,12 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesOut += n
+	return n, err
+}
+
 // joinStrings joins strings with a separator.
 func joinStrings(strs []string, sep string) string {
 	if len(strs) == 0 {
@@ -0,0 +1,404 @@
+package httpware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HeaderIdempotencyKey is the header clients set to make an unsafe request
+// idempotent.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// IdempotentResponse is the buffered result of the first execution for an
+// idempotency key, replayed verbatim on retry.
+type IdempotentResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// BodyHash is the SHA-256 of the request body that produced this
+	// response. A retry with the same key but a different BodyHash is
+	// rejected with 422, per the Stripe-style idempotency contract.
+	BodyHash string
+}
+
+// IdempotencyStore is the pluggable backend for idempotency state and its
+// distributed lock. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Lock acquires a distributed lock for key (analogous to Redis SETNX),
+	// expiring automatically after ttl, and reports whether it was acquired.
+	// On success it returns a unique token identifying this acquisition;
+	// only Unlock called with that same token can release it, so a caller
+	// whose lock already expired (e.g. a slow handler outliving ttl) can
+	// never release a lock a later caller has since acquired.
+	Lock(ctx context.Context, key string, ttl time.Duration) (acquired bool, token string, err error)
+
+	// Unlock releases the lock on key, but only if it is still held with
+	// the given token (compare-and-delete). Releasing a lock that has
+	// since been re-acquired by another caller must be a no-op.
+	Unlock(ctx context.Context, key string, token string) error
+
+	// Get returns the stored response for key, or nil if none is stored yet.
+	Get(ctx context.Context, key string) (*IdempotentResponse, error)
+
+	// Save stores resp for key with the given TTL.
+	Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error
+}
+
+// IdempotencyConfig configures the Idempotency middleware.
+type IdempotencyConfig struct {
+	// Store holds responses and locks. Defaults to NewInMemoryIdempotencyStore().
+	Store IdempotencyStore
+
+	// TTL is how long a stored response is replayed before expiring.
+	// Defaults to 24h.
+	TTL time.Duration
+
+	// LockTTL bounds how long the first caller's lock is held, in case it
+	// never completes. Defaults to 10s.
+	LockTTL time.Duration
+
+	// LockTimeout is how long a concurrent caller waits for the first
+	// caller's result before giving up. Defaults to 5s.
+	LockTimeout time.Duration
+
+	// LockPollInterval is how often a concurrent caller polls for the
+	// first caller's result while waiting. Defaults to 50ms.
+	LockPollInterval time.Duration
+}
+
+// Idempotency middleware makes POST/PUT/PATCH/DELETE requests carrying an
+// Idempotency-Key header idempotent: the handler runs once per
+// (tenant, key, method, path) tuple, its response is buffered and stored,
+// and retries replay the stored response instead of re-executing. Concurrent
+// retries with the same key block on a distributed lock and receive the
+// first caller's result. Reusing a key with a different request body
+// returns 422 Unprocessable Entity.
+func Idempotency(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	if cfg.Store == nil {
+		cfg.Store = NewInMemoryIdempotencyStore()
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.LockTTL == 0 {
+		cfg.LockTTL = 10 * time.Second
+	}
+	if cfg.LockTimeout == 0 {
+		cfg.LockTimeout = 5 * time.Second
+	}
+	if cfg.LockPollInterval == 0 {
+		cfg.LockPollInterval = 50 * time.Millisecond
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isUnsafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				renderError(w, NewValidationError("failed to read request body"), GetRequestID(r.Context()))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			bodyHash := hashBody(body)
+
+			storeKey := fmt.Sprintf("%s:%s:%s:%s", GetTenantID(r.Context()), key, r.Method, r.URL.Path)
+			ctx := r.Context()
+
+			if cached, err := cfg.Store.Get(ctx, storeKey); err == nil && cached != nil {
+				replayIdempotent(w, ctx, cached, bodyHash)
+				return
+			}
+
+			acquired, token, err := cfg.Store.Lock(ctx, storeKey, cfg.LockTTL)
+			if err != nil {
+				renderError(w, NewRequestError(http.StatusInternalServerError, "idempotency store unavailable").WithCode("store_unavailable").WithCause(err), GetRequestID(ctx))
+				return
+			}
+
+			if !acquired {
+				deadline := time.Now().Add(cfg.LockTimeout)
+				for time.Now().Before(deadline) {
+					time.Sleep(cfg.LockPollInterval)
+					if cached, err := cfg.Store.Get(ctx, storeKey); err == nil && cached != nil {
+						replayIdempotent(w, ctx, cached, bodyHash)
+						return
+					}
+				}
+				renderError(w, NewRequestError(http.StatusConflict, "a request with this idempotency key is already in progress").WithCode("conflict"), GetRequestID(ctx))
+				return
+			}
+			defer cfg.Store.Unlock(ctx, storeKey, token)
+
+			buf := &bufferedResponseWriter{header: make(http.Header), statusCode: http.StatusOK}
+			next.ServeHTTP(buf, r)
+
+			resp := &IdempotentResponse{
+				StatusCode: buf.statusCode,
+				Header:     buf.header,
+				Body:       buf.body.Bytes(),
+				BodyHash:   bodyHash,
+			}
+			_ = cfg.Store.Save(ctx, storeKey, resp, cfg.TTL)
+
+			writeIdempotent(w, resp)
+		})
+	}
+}
+
+// isUnsafeMethod reports whether method is one the Idempotency middleware
+// applies to.
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// hashBody returns the hex-encoded SHA-256 of body.
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// generateLockToken returns a random token identifying one lock acquisition,
+// so Unlock can compare-and-delete rather than unconditionally deleting.
+func generateLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("httpware: generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// replayIdempotent writes cached to w, or a 422 if bodyHash does not match
+// the request that originally produced it.
+func replayIdempotent(w http.ResponseWriter, ctx context.Context, cached *IdempotentResponse, bodyHash string) {
+	if cached.BodyHash != bodyHash {
+		renderError(w, NewRequestError(http.StatusUnprocessableEntity, "Idempotency-Key reused with a different request body").WithCode("idempotency_key_reused"), GetRequestID(ctx))
+		return
+	}
+	writeIdempotent(w, cached)
+}
+
+// writeIdempotent writes a stored response verbatim.
+func writeIdempotent(w http.ResponseWriter, resp *IdempotentResponse) {
+	for k, values := range resp.Header {
+		for _, v := range values {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = w.Write(resp.Body)
+}
+
+// bufferedResponseWriter captures a handler's response so it can be stored
+// and replayed later.
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+	wroteHead  bool
+}
+
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+func (bw *bufferedResponseWriter) WriteHeader(code int) {
+	if bw.wroteHead {
+		return
+	}
+	bw.statusCode = code
+	bw.wroteHead = true
+}
+
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if !bw.wroteHead {
+		bw.WriteHeader(http.StatusOK)
+	}
+	return bw.body.Write(b)
+}
+
+// idempotencyEntry is a stored response with its expiry, used by
+// InMemoryIdempotencyStore.
+type idempotencyEntry struct {
+	resp      *IdempotentResponse
+	expiresAt time.Time
+}
+
+// idempotencyLock is a held lock's owner token and expiry, used by
+// InMemoryIdempotencyStore.
+type idempotencyLock struct {
+	token     string
+	expiresAt time.Time
+}
+
+// InMemoryIdempotencyStore is a process-local IdempotencyStore. It is the
+// default store and is suitable for single-instance deployments; use
+// RedisIdempotencyStore for horizontally-scaled services.
+type InMemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	locks   map[string]idempotencyLock
+	entries map[string]idempotencyEntry
+}
+
+// NewInMemoryIdempotencyStore creates an empty InMemoryIdempotencyStore.
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{
+		locks:   make(map[string]idempotencyLock),
+		entries: make(map[string]idempotencyEntry),
+	}
+}
+
+// Lock implements IdempotencyStore.
+func (s *InMemoryIdempotencyStore) Lock(_ context.Context, key string, ttl time.Duration) (bool, string, error) {
+	token, err := generateLockToken()
+	if err != nil {
+		return false, "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lock, ok := s.locks[key]; ok && time.Now().Before(lock.expiresAt) {
+		return false, "", nil
+	}
+	s.locks[key] = idempotencyLock{token: token, expiresAt: time.Now().Add(ttl)}
+	return true, token, nil
+}
+
+// Unlock implements IdempotencyStore. It only releases the lock if token
+// still matches the current holder, so a caller whose lock already expired
+// and was re-acquired by someone else cannot evict that new holder.
+func (s *InMemoryIdempotencyStore) Unlock(_ context.Context, key string, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lock, ok := s.locks[key]; ok && lock.token == token {
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// Get implements IdempotencyStore.
+func (s *InMemoryIdempotencyStore) Get(_ context.Context, key string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, nil
+	}
+	return entry.resp, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *InMemoryIdempotencyStore) Save(_ context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = idempotencyEntry{resp: resp, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// RedisIdempotencyStore is an IdempotencyStore backed by Redis, sharing
+// state across all instances of a horizontally-scaled service.
+type RedisIdempotencyStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisIdempotencyStore creates a RedisIdempotencyStore. keyPrefix
+// namespaces keys within a shared Redis instance (e.g. "httpware:idempotency:").
+func NewRedisIdempotencyStore(client *redis.Client, keyPrefix string) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client, prefix: keyPrefix}
+}
+
+// redisUnlockScript deletes the lock key only if its value still matches the
+// caller's token, so releasing an already-expired (and possibly
+// re-acquired) lock can never evict a different holder. This is the
+// standard safe-unlock pattern for a Redis-backed mutex.
+var redisUnlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+else
+	return 0
+end
+`)
+
+// Lock implements IdempotencyStore using SETNX with a TTL, storing a unique
+// token as the value so Unlock can compare-and-delete.
+func (s *RedisIdempotencyStore) Lock(ctx context.Context, key string, ttl time.Duration) (bool, string, error) {
+	token, err := generateLockToken()
+	if err != nil {
+		return false, "", err
+	}
+
+	ok, err := s.client.SetNX(ctx, s.prefix+"lock:"+key, token, ttl).Result()
+	if err != nil {
+		return false, "", fmt.Errorf("httpware: redis idempotency lock: %w", err)
+	}
+	if !ok {
+		return false, "", nil
+	}
+	return true, token, nil
+}
+
+// Unlock implements IdempotencyStore via a compare-and-delete Lua script, so
+// it only releases the lock if token still matches the current holder.
+func (s *RedisIdempotencyStore) Unlock(ctx context.Context, key string, token string) error {
+	if err := redisUnlockScript.Run(ctx, s.client, []string{s.prefix + "lock:" + key}, token).Err(); err != nil {
+		return fmt.Errorf("httpware: redis idempotency unlock: %w", err)
+	}
+	return nil
+}
+
+// Get implements IdempotencyStore.
+func (s *RedisIdempotencyStore) Get(ctx context.Context, key string) (*IdempotentResponse, error) {
+	raw, err := s.client.Get(ctx, s.prefix+"resp:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("httpware: redis idempotency get: %w", err)
+	}
+
+	var resp IdempotentResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("httpware: decode idempotency response: %w", err)
+	}
+	return &resp, nil
+}
+
+// Save implements IdempotencyStore.
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, resp *IdempotentResponse, ttl time.Duration) error {
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return fmt.Errorf("httpware: encode idempotency response: %w", err)
+	}
+	if err := s.client.Set(ctx, s.prefix+"resp:"+key, raw, ttl).Err(); err != nil {
+		return fmt.Errorf("httpware: redis idempotency save: %w", err)
+	}
+	return nil
+}
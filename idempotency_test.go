@@ -0,0 +1,68 @@
+package httpware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestInMemoryIdempotencyStore_LockUnlockRoundTrip(t *testing.T) {
+	store := NewInMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	acquired, token, err := store.Lock(ctx, "key", time.Minute)
+	if err != nil || !acquired || token == "" {
+		t.Fatalf("Lock() = %v, %q, %v; want acquired with a token", acquired, token, err)
+	}
+
+	if acquired, _, err := store.Lock(ctx, "key", time.Minute); err != nil || acquired {
+		t.Fatalf("Lock() while held = %v, %v; want not acquired", acquired, err)
+	}
+
+	if err := store.Unlock(ctx, "key", token); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	if acquired, _, err := store.Lock(ctx, "key", time.Minute); err != nil || !acquired {
+		t.Fatalf("Lock() after Unlock() = %v, %v; want acquired", acquired, err)
+	}
+}
+
+// TestInMemoryIdempotencyStore_StaleUnlockDoesNotEvictNewHolder guards
+// against the race a slow handler can trigger: if the first caller's lock
+// outlives its TTL, a second caller acquires a fresh lock with its own
+// token. The first caller's deferred Unlock (with its now-stale token) must
+// not evict the second caller's lock — otherwise a third caller could start
+// a concurrent execution of the same idempotency key, defeating the
+// single-execution guarantee.
+func TestInMemoryIdempotencyStore_StaleUnlockDoesNotEvictNewHolder(t *testing.T) {
+	store := NewInMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	acquired, staleToken, err := store.Lock(ctx, "key", 10*time.Millisecond)
+	if err != nil || !acquired {
+		t.Fatalf("initial Lock() = %v, %v; want acquired", acquired, err)
+	}
+
+	time.Sleep(20 * time.Millisecond) // let the lock expire
+
+	acquired, newToken, err := store.Lock(ctx, "key", time.Minute)
+	if err != nil || !acquired || newToken == staleToken {
+		t.Fatalf("re-Lock() after expiry = %v, %q, %v; want acquired with a fresh token", acquired, newToken, err)
+	}
+
+	if err := store.Unlock(ctx, "key", staleToken); err != nil {
+		t.Fatalf("stale Unlock() error = %v", err)
+	}
+
+	if acquired, _, err := store.Lock(ctx, "key", time.Minute); err != nil || acquired {
+		t.Fatalf("Lock() after stale Unlock() = %v, %v; want still held by the new owner", acquired, err)
+	}
+
+	if err := store.Unlock(ctx, "key", newToken); err != nil {
+		t.Fatalf("valid Unlock() error = %v", err)
+	}
+	if acquired, _, err := store.Lock(ctx, "key", time.Minute); err != nil || !acquired {
+		t.Fatalf("Lock() after valid Unlock() = %v, %v; want acquired", acquired, err)
+	}
+}
@@ -0,0 +1,143 @@
+package httpware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsConfig configures the Metrics middleware.
+type MetricsConfig struct {
+	// Registerer registers the collectors. Defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// DurationBuckets are the histogram buckets for http_request_duration_seconds.
+	// Defaults to prometheus.DefBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets are the histogram buckets for http_response_size_bytes.
+	// Defaults to prometheus.ExponentialBuckets(100, 10, 8).
+	SizeBuckets []float64
+
+	// RouteNameResolver returns the low-cardinality route pattern for a
+	// request (e.g. chi.RouteContext(r.Context()).RoutePattern()), avoiding
+	// high-cardinality labels from raw URL params. Defaults to r.URL.Path.
+	RouteNameResolver func(r *http.Request) string
+}
+
+// Metrics middleware records the RED signals for every request:
+// http_requests_total{method,route,status,tenant}, http_request_duration_seconds,
+// http_requests_in_flight, and http_response_size_bytes, using
+// prometheus/client_golang. Pair it with MetricsHandler to expose the
+// /metrics scrape endpoint.
+func Metrics(cfg MetricsConfig) func(http.Handler) http.Handler {
+	if cfg.Registerer == nil {
+		cfg.Registerer = prometheus.DefaultRegisterer
+	}
+	if cfg.DurationBuckets == nil {
+		cfg.DurationBuckets = prometheus.DefBuckets
+	}
+	if cfg.SizeBuckets == nil {
+		cfg.SizeBuckets = prometheus.ExponentialBuckets(100, 10, 8)
+	}
+	if cfg.RouteNameResolver == nil {
+		cfg.RouteNameResolver = func(r *http.Request) string { return r.URL.Path }
+	}
+
+	requestsTotal := registerCounterVec(cfg.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total HTTP requests processed, labeled by method, route, status, and tenant.",
+	}, []string{"method", "route", "status", "tenant"}))
+
+	requestDuration := registerHistogramVec(cfg.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request duration in seconds, labeled by method, route, and status.",
+		Buckets: cfg.DurationBuckets,
+	}, []string{"method", "route", "status"}))
+
+	requestsInFlight := registerGauge(cfg.Registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	}))
+
+	responseSize := registerHistogramVec(cfg.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_response_size_bytes",
+		Help:    "HTTP response size in bytes, labeled by method and route.",
+		Buckets: cfg.SizeBuckets,
+	}, []string{"method", "route"}))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := cfg.RouteNameResolver(r)
+
+			requestsInFlight.Inc()
+			defer requestsInFlight.Dec()
+
+			start := time.Now()
+			ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(ww, r)
+
+			status := strconv.Itoa(ww.statusCode)
+			requestsTotal.WithLabelValues(r.Method, route, status, GetTenantID(r.Context())).Inc()
+			requestDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+			responseSize.WithLabelValues(r.Method, route).Observe(float64(ww.bytesOut))
+		})
+	}
+}
+
+// registerCounterVec registers c with reg, returning the already-registered
+// collector instead of panicking if Metrics has run before against the same
+// Registerer (e.g. a second call in tests, or a service that builds its
+// router more than once per process).
+func registerCounterVec(reg prometheus.Registerer, c *prometheus.CounterVec) *prometheus.CounterVec {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.CounterVec); ok {
+				return existing
+			}
+		}
+	}
+	return c
+}
+
+// registerHistogramVec is registerCounterVec for *prometheus.HistogramVec.
+func registerHistogramVec(reg prometheus.Registerer, c *prometheus.HistogramVec) *prometheus.HistogramVec {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(*prometheus.HistogramVec); ok {
+				return existing
+			}
+		}
+	}
+	return c
+}
+
+// registerGauge is registerCounterVec for prometheus.Gauge.
+func registerGauge(reg prometheus.Registerer, c prometheus.Gauge) prometheus.Gauge {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			if existing, ok := are.ExistingCollector.(prometheus.Gauge); ok {
+				return existing
+			}
+		}
+	}
+	return c
+}
+
+// MetricsHandler returns the Prometheus scrape handler for the given
+// Gatherer. Pass the same value used as MetricsConfig.Registerer (registries
+// satisfy both interfaces); pass nil to scrape prometheus.DefaultGatherer.
+func MetricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
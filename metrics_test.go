@@ -0,0 +1,58 @@
+package httpware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetrics_DuplicateRegistrationDoesNotPanic(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := MetricsConfig{Registerer: reg}
+
+	mw1 := Metrics(cfg)
+	mw2 := Metrics(cfg) // previously panicked via MustRegister on the second call
+
+	handler := mw2(mw1(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/widgets", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestMetrics_RecordsRequestTotal(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	handler := Metrics(MetricsConfig{Registerer: reg})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/widgets", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+
+	var found bool
+	for _, mf := range families {
+		if mf.GetName() == "http_requests_total" {
+			found = true
+			if len(mf.GetMetric()) != 1 {
+				t.Fatalf("http_requests_total has %d series, want 1", len(mf.GetMetric()))
+			}
+		}
+	}
+	if !found {
+		t.Fatal("http_requests_total not found in gathered metrics")
+	}
+}
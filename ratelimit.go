@@ -0,0 +1,288 @@
+package httpware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimitExemptKey is the context key for the rate-limit bypass marker.
+const RateLimitExemptKey contextKey = "ratelimit_exempt"
+
+// Rate describes a token-bucket rate: requests per second with a burst
+// allowance.
+type Rate struct {
+	RPS   float64
+	Burst int
+}
+
+// RateLimitStore is the pluggable backend for token-bucket state. Implementations
+// must be safe for concurrent use.
+type RateLimitStore interface {
+	// Allow consumes one token from the bucket identified by key, refilling it
+	// according to rate based on elapsed time since the last call. It reports
+	// whether the request is allowed, how many tokens remain, and when the
+	// bucket will be refilled to its full burst.
+	Allow(ctx context.Context, key string, rate Rate) (allowed bool, remaining int, resetAt time.Time, err error)
+}
+
+// RateLimitConfig configures the RateLimit middleware.
+type RateLimitConfig struct {
+	// Store holds token-bucket state. Defaults to NewInMemoryRateLimitStore().
+	Store RateLimitStore
+
+	// Default is the rate applied when no entry in Limits matches the request.
+	Default Rate
+
+	// Limits overrides the default rate for specific routes, keyed by
+	// "METHOD pattern" (e.g. "POST /v1/orders/{id}"). The pattern is
+	// resolved via RouteNameResolver, so parameterized routes match their
+	// override regardless of the literal request path.
+	Limits map[string]Rate
+
+	// KeyFunc resolves the bucket key for a request. Defaults to tenant ID,
+	// falling back to user ID, falling back to client IP.
+	KeyFunc func(r *http.Request) string
+
+	// RouteNameResolver returns the low-cardinality route pattern for a
+	// request (e.g. chi.RouteContext(r.Context()).RoutePattern()), used to
+	// look up Limits. Defaults to r.URL.Path, which only matches literal
+	// (non-parameterized) routes.
+	RouteNameResolver func(r *http.Request) string
+}
+
+// RateLimit middleware enforces token-bucket rate limits per tenant (falling
+// back to user ID, then client IP). Requests exceeding the limit receive
+// 429 Too Many Requests with standard RateLimit-* and Retry-After headers.
+// Requests whose context is marked exempt (see WithRateLimitExempt) skip
+// enforcement entirely.
+func RateLimit(cfg RateLimitConfig) func(http.Handler) http.Handler {
+	if cfg.Store == nil {
+		cfg.Store = NewInMemoryRateLimitStore()
+	}
+	if cfg.KeyFunc == nil {
+		cfg.KeyFunc = defaultRateLimitKey
+	}
+	if cfg.RouteNameResolver == nil {
+		cfg.RouteNameResolver = func(r *http.Request) string { return r.URL.Path }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if IsRateLimitExempt(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rate := cfg.Default
+			if override, ok := cfg.Limits[r.Method+" "+cfg.RouteNameResolver(r)]; ok {
+				rate = override
+			}
+			if rate.RPS <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cfg.KeyFunc(r)
+			allowed, remaining, resetAt, err := cfg.Store.Allow(r.Context(), key, rate)
+			if err != nil {
+				// Fail open: a misbehaving store should not take down the service.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(rate.Burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+
+			if !allowed {
+				retryAfter := int(time.Until(resetAt).Seconds())
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// defaultRateLimitKey resolves the bucket key from tenant ID, falling back to
+// user ID, falling back to client IP.
+func defaultRateLimitKey(r *http.Request) string {
+	if tenantID := GetTenantID(r.Context()); tenantID != "" {
+		return "tenant:" + tenantID
+	}
+	if userID := GetUserID(r.Context()); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + clientIP(r)
+}
+
+// clientIP returns the request's client IP, preferring the left-most
+// X-Forwarded-For entry before falling back to RemoteAddr.
+func clientIP(r *http.Request) string {
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		if ip := strings.TrimSpace(strings.Split(fwd, ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	host := r.RemoteAddr
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		host = host[:i]
+	}
+	return host
+}
+
+// WithRateLimitExempt marks the context so RateLimit bypasses enforcement.
+// Intended for internal admin handlers mounted behind the same middleware
+// stack as public routes.
+func WithRateLimitExempt(ctx context.Context) context.Context {
+	return context.WithValue(ctx, RateLimitExemptKey, true)
+}
+
+// IsRateLimitExempt reports whether the context was marked via
+// WithRateLimitExempt.
+func IsRateLimitExempt(ctx context.Context) bool {
+	exempt, _ := ctx.Value(RateLimitExemptKey).(bool)
+	return exempt
+}
+
+// tokenBucket tracks the in-memory state of a single rate-limit key.
+type tokenBucket struct {
+	mu       sync.Mutex
+	tokens   float64
+	lastSeen time.Time
+}
+
+// InMemoryRateLimitStore is a process-local RateLimitStore backed by
+// sync.Map. It is the default store and is suitable for single-instance
+// deployments; use RedisRateLimitStore for horizontal scaling.
+type InMemoryRateLimitStore struct {
+	buckets sync.Map // key -> *tokenBucket
+}
+
+// NewInMemoryRateLimitStore creates an empty InMemoryRateLimitStore.
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{}
+}
+
+// Allow implements RateLimitStore.
+func (s *InMemoryRateLimitStore) Allow(_ context.Context, key string, rate Rate) (bool, int, time.Time, error) {
+	v, _ := s.buckets.LoadOrStore(key, &tokenBucket{tokens: float64(rate.Burst), lastSeen: time.Now()})
+	b := v.(*tokenBucket)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.lastSeen = now
+
+	b.tokens += elapsed * rate.RPS
+	if b.tokens > float64(rate.Burst) {
+		b.tokens = float64(rate.Burst)
+	}
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	remaining := int(b.tokens)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	var resetAt time.Time
+	if b.tokens >= float64(rate.Burst) || rate.RPS <= 0 {
+		resetAt = now
+	} else {
+		missing := float64(rate.Burst) - b.tokens
+		resetAt = now.Add(time.Duration(missing / rate.RPS * float64(time.Second)))
+	}
+
+	return allowed, remaining, resetAt, nil
+}
+
+// redisRateLimitScript implements the same refill arithmetic as
+// InMemoryRateLimitStore, atomically, so concurrent instances share one
+// bucket per key.
+var redisRateLimitScript = redis.NewScript(`
+local key = KEYS[1]
+local rps = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", key, "tokens", "ts")
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(burst, tokens + elapsed * rps)
+
+local allowed = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - 1
+end
+
+redis.call("HMSET", key, "tokens", tokens, "ts", now)
+redis.call("EXPIRE", key, math.ceil(burst / rps) + 1)
+
+return {allowed, tokens}
+`)
+
+// RedisRateLimitStore is a RateLimitStore backed by Redis, sharing bucket
+// state across all instances of a horizontally-scaled service.
+type RedisRateLimitStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRateLimitStore creates a RedisRateLimitStore. keyPrefix namespaces
+// keys within a shared Redis instance (e.g. "httpware:ratelimit:").
+func NewRedisRateLimitStore(client *redis.Client, keyPrefix string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: keyPrefix}
+}
+
+// Allow implements RateLimitStore.
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, rate Rate) (bool, int, time.Time, error) {
+	now := time.Now()
+	res, err := redisRateLimitScript.Run(ctx, s.client, []string{s.prefix + key}, rate.RPS, rate.Burst, float64(now.UnixNano())/1e9).Result()
+	if err != nil {
+		return false, 0, now, fmt.Errorf("httpware: redis rate limit: %w", err)
+	}
+
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 2 {
+		return false, 0, now, fmt.Errorf("httpware: unexpected redis rate limit reply: %v", res)
+	}
+
+	allowed := fmt.Sprint(vals[0]) == "1"
+	tokens, _ := strconv.ParseFloat(fmt.Sprint(vals[1]), 64)
+	remaining := int(tokens)
+
+	var resetAt time.Time
+	if tokens >= float64(rate.Burst) || rate.RPS <= 0 {
+		resetAt = now
+	} else {
+		missing := float64(rate.Burst) - tokens
+		resetAt = now.Add(time.Duration(missing / rate.RPS * float64(time.Second)))
+	}
+
+	return allowed, remaining, resetAt, nil
+}
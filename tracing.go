@@ -0,0 +1,124 @@
+package httpware
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/propagators/b3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package as an OpenTelemetry tracer.
+const instrumentationName = "github.com/Bengo-Hub/httpware"
+
+// TracingConfig configures the Tracing middleware.
+type TracingConfig struct {
+	// ServiceName sets the service.name resource attribute of the tracer
+	// provider built from Sampler. Ignored if TracerProvider is set or
+	// Sampler is nil.
+	ServiceName string
+
+	// TracerProvider supplies the tracer. Defaults to otel.GetTracerProvider();
+	// if Sampler is also set, a dedicated provider is built from it instead.
+	TracerProvider trace.TracerProvider
+
+	// Sampler, if set, builds a dedicated TracerProvider instead of using
+	// the global one. Has no effect if TracerProvider is set explicitly.
+	Sampler sdktrace.Sampler
+
+	// Propagator extracts/injects trace context from inbound headers.
+	// Defaults to W3C tracecontext + baggage, with B3 as a fallback.
+	Propagator propagation.TextMapPropagator
+
+	// RouteNameResolver returns the low-cardinality route pattern for a
+	// request (e.g. chi.RouteContext(r.Context()).RoutePattern()). Defaults
+	// to r.URL.Path.
+	RouteNameResolver func(r *http.Request) string
+}
+
+// Tracing middleware extracts W3C traceparent/tracestate (falling back to B3)
+// from inbound request headers, starts a server span, and injects the span
+// context into the request context. It records http.method, http.route,
+// http.status_code, tenant_id, and request_id as span attributes.
+func Tracing(cfg TracingConfig) func(http.Handler) http.Handler {
+	if cfg.TracerProvider == nil {
+		if cfg.Sampler != nil {
+			opts := []sdktrace.TracerProviderOption{sdktrace.WithSampler(cfg.Sampler)}
+			if cfg.ServiceName != "" {
+				opts = append(opts, sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName))))
+			}
+			cfg.TracerProvider = sdktrace.NewTracerProvider(opts...)
+		} else {
+			cfg.TracerProvider = otel.GetTracerProvider()
+		}
+	}
+	if cfg.Propagator == nil {
+		// Each propagator's Extract overrides what earlier ones found, so B3
+		// goes first and W3C wins when both header sets are present.
+		cfg.Propagator = propagation.NewCompositeTextMapPropagator(
+			b3.New(),
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		)
+	}
+	if cfg.RouteNameResolver == nil {
+		cfg.RouteNameResolver = func(r *http.Request) string { return r.URL.Path }
+	}
+
+	tracer := cfg.TracerProvider.Tracer(instrumentationName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := cfg.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			route := cfg.RouteNameResolver(r)
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer))
+			defer span.End()
+
+			span.SetAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("http.route", route),
+			)
+			if tenantID := GetTenantID(ctx); tenantID != "" {
+				span.SetAttributes(attribute.String("tenant_id", tenantID))
+			}
+			if requestID := GetRequestID(ctx); requestID != "" {
+				span.SetAttributes(attribute.String("request_id", requestID))
+			}
+
+			ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.status_code", ww.statusCode))
+			if ww.statusCode >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(ww.statusCode))
+			}
+		})
+	}
+}
+
+// TraceIDFromContext returns the hex-encoded trace ID of the span stored in
+// ctx, or an empty string if ctx carries no valid span context.
+func TraceIDFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// SpanIDFromContext returns the hex-encoded span ID of the span stored in
+// ctx, or an empty string if ctx carries no valid span context.
+func SpanIDFromContext(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.SpanID().String()
+}
@@ -0,0 +1,223 @@
+// Package webhook provides a trusted client for calling external webhooks
+// from BengoBox services. It mirrors the inbound httpware request ID
+// convention for outbound calls: every payload is HMAC-signed, request IDs
+// flow from context, and transient failures are retried with exponential
+// backoff and jitter. OpenTelemetry trace context is not injected into
+// outbound requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	httpware "github.com/Bengo-Hub/httpware"
+)
+
+// HeaderSignature is the header carrying the HMAC signature, in the form
+// "t=<unix>,v1=<hex>".
+const HeaderSignature = "X-Signature"
+
+// HeaderWebhookID is the header carrying a unique ID for the delivery. The
+// same ID is sent on every retry of that delivery.
+const HeaderWebhookID = "X-Webhook-ID"
+
+// Config configures a Client.
+type Config struct {
+	// Secret signs outbound payloads with HMAC-SHA256. Required.
+	Secret string
+
+	// HTTPClient performs the underlying requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Timeout bounds each individual delivery attempt, including retries.
+	// Defaults to 10s.
+	Timeout time.Duration
+
+	// MaxRetries is the number of retry attempts after the first try on
+	// 5xx responses or network errors. Defaults to 3.
+	MaxRetries int
+
+	// BaseDelay is the initial backoff delay, doubled on each retry and
+	// randomized with full jitter. Defaults to 200ms.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff delay. Defaults to 5s.
+	MaxDelay time.Duration
+}
+
+// Client sends signed, retried webhook deliveries.
+type Client struct {
+	httpClient *http.Client
+	secret     []byte
+	timeout    time.Duration
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+// NewClient creates a Client from cfg, applying defaults for zero-valued fields.
+func NewClient(cfg Config) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.BaseDelay == 0 {
+		cfg.BaseDelay = 200 * time.Millisecond
+	}
+	if cfg.MaxDelay == 0 {
+		cfg.MaxDelay = 5 * time.Second
+	}
+
+	return &Client{
+		httpClient: cfg.HTTPClient,
+		secret:     []byte(cfg.Secret),
+		timeout:    cfg.Timeout,
+		maxRetries: cfg.MaxRetries,
+		baseDelay:  cfg.BaseDelay,
+		maxDelay:   cfg.MaxDelay,
+	}
+}
+
+// Send POSTs body to url, signing it and propagating X-Request-ID from ctx
+// (see httpware.GetRequestID). It retries on 5xx responses and network
+// errors with exponential backoff and full jitter, up to MaxRetries times,
+// and returns the first 2xx-4xx response or the last error.
+func (c *Client) Send(ctx context.Context, url string, body []byte) (*http.Response, error) {
+	webhookID := uuid.New().String()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := c.backoff(attempt)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		resp, err := c.deliver(ctx, url, body, webhookID)
+		if err == nil && resp.StatusCode < 500 {
+			return resp, nil
+		}
+		if err == nil {
+			resp.Body.Close()
+			lastErr = fmt.Errorf("webhook: server returned %d", resp.StatusCode)
+			continue
+		}
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("webhook: delivery failed after %d attempts: %w", c.maxRetries+1, lastErr)
+}
+
+// deliver performs a single signed delivery attempt, bounded by the client's
+// configured per-attempt timeout.
+func (c *Client) deliver(ctx context.Context, url string, body []byte, webhookID string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("webhook: build request: %w", err)
+	}
+
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookID, webhookID)
+	req.Header.Set(HeaderSignature, fmt.Sprintf("t=%d,v1=%s", ts, sign(c.secret, ts, body)))
+	if requestID := httpware.GetRequestID(ctx); requestID != "" {
+		req.Header.Set(httpware.HeaderRequestID, requestID)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// backoff returns the delay before the given retry attempt (1-indexed),
+// doubling per attempt and applying full jitter, capped at maxDelay.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.baseDelay << uint(attempt-1)
+	if delay > c.maxDelay || delay <= 0 {
+		delay = c.maxDelay
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(delay)))
+	if err != nil {
+		return delay
+	}
+	return time.Duration(n.Int64())
+}
+
+// sign computes the hex-encoded HMAC-SHA256 of "<timestamp>.<body>" using secret.
+func sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that header (the value of HeaderSignature) is a valid
+// HMAC-SHA256 signature of body under secret, and that its timestamp is
+// within maxSkew of now. Receivers should read body before any other
+// processing and reject the request if Verify returns an error.
+func Verify(secret string, header string, body []byte, maxSkew time.Duration) error {
+	ts, sig, err := parseSignatureHeader(header)
+	if err != nil {
+		return err
+	}
+
+	skew := time.Since(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > maxSkew {
+		return fmt.Errorf("webhook: signature timestamp outside allowed skew of %s", maxSkew)
+	}
+
+	expected := sign([]byte(secret), ts, body)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return fmt.Errorf("webhook: signature mismatch")
+	}
+
+	return nil
+}
+
+// parseSignatureHeader parses "t=<unix>,v1=<hex>" into its components.
+func parseSignatureHeader(header string) (timestamp int64, signature string, err error) {
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp, err = strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return 0, "", fmt.Errorf("webhook: invalid timestamp in signature header: %w", err)
+			}
+		case "v1":
+			signature = kv[1]
+		}
+	}
+	if timestamp == 0 || signature == "" {
+		return 0, "", fmt.Errorf("webhook: malformed signature header %q", header)
+	}
+	return timestamp, signature, nil
+}
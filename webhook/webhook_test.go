@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestVerify_ValidSignature(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte(`{"event":"order.created"}`)
+	ts := time.Now().Unix()
+	header := fmt.Sprintf("t=%d,v1=%s", ts, sign([]byte(secret), ts, body))
+
+	if err := Verify(secret, header, body, time.Minute); err != nil {
+		t.Fatalf("Verify() error = %v, want nil", err)
+	}
+}
+
+func TestVerify_RejectsTamperedBody(t *testing.T) {
+	secret := "s3cr3t"
+	ts := time.Now().Unix()
+	header := fmt.Sprintf("t=%d,v1=%s", ts, sign([]byte(secret), ts, []byte(`{"event":"order.created"}`)))
+
+	if err := Verify(secret, header, []byte(`{"event":"order.cancelled"}`), time.Minute); err == nil {
+		t.Fatal("Verify() error = nil, want mismatch error for a tampered body")
+	}
+}
+
+func TestVerify_RejectsWrongSecret(t *testing.T) {
+	body := []byte(`{"event":"order.created"}`)
+	ts := time.Now().Unix()
+	header := fmt.Sprintf("t=%d,v1=%s", ts, sign([]byte("correct-secret"), ts, body))
+
+	if err := Verify("wrong-secret", header, body, time.Minute); err == nil {
+		t.Fatal("Verify() error = nil, want mismatch error for the wrong secret")
+	}
+}
+
+func TestVerify_RejectsStaleTimestamp(t *testing.T) {
+	secret := "s3cr3t"
+	body := []byte(`{"event":"order.created"}`)
+	ts := time.Now().Add(-time.Hour).Unix()
+	header := fmt.Sprintf("t=%d,v1=%s", ts, sign([]byte(secret), ts, body))
+
+	if err := Verify(secret, header, body, time.Minute); err == nil {
+		t.Fatal("Verify() error = nil, want skew error for a stale timestamp")
+	}
+}
+
+func TestVerify_RejectsMalformedHeader(t *testing.T) {
+	cases := []string{"", "t=123", "v1=abc", "t=notanumber,v1=abc"}
+	for _, header := range cases {
+		if err := Verify("s3cr3t", header, []byte("body"), time.Minute); err == nil {
+			t.Errorf("Verify(header=%q) error = nil, want error", header)
+		}
+	}
+}